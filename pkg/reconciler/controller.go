@@ -0,0 +1,304 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/dougbtv/whereabouts/pkg/logging"
+	"github.com/dougbtv/whereabouts/pkg/storage/kubernetes"
+)
+
+const (
+	// OrphanGracePeriodEnvVar overrides how long the controller waits
+	// after a pod-delete event before reconciling the pools it might have
+	// touched, absorbing the eventual-consistency window between a pod
+	// disappearing and its IPPool allocation catching up.
+	OrphanGracePeriodEnvVar  = "WHEREABOUTS_ORPHAN_GRACE_PERIOD"
+	defaultOrphanGracePeriod = 30 * time.Second
+
+	leaseName = "whereabouts-reconciler"
+
+	// poolResyncKey is the work-queue item enqueued whenever an IPPool or
+	// OverlappingRangeIPReservation changes, standing in for "something
+	// pool-side moved, take a full pass" rather than a single podref.
+	poolResyncKey = "__pool_resync__"
+)
+
+var (
+	orphansDetectedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "whereabouts_orphans_detected_total",
+		Help: "Total number of orphaned IP reservations detected by the reconciler controller.",
+	})
+	orphansReleasedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "whereabouts_orphans_released_total",
+		Help: "Total number of orphaned IP reservations released by the reconciler controller.",
+	})
+	reconcileErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "whereabouts_reconcile_errors_total",
+		Help: "Total number of errors encountered while reconciling IP pools.",
+	})
+
+	ippoolGVR = schema.GroupVersionResource{
+		Group:    "whereabouts.cni.cncf.io",
+		Version:  "v1alpha1",
+		Resource: "ippools",
+	}
+	overlappingRangeIPReservationGVR = schema.GroupVersionResource{
+		Group:    "whereabouts.cni.cncf.io",
+		Version:  "v1alpha1",
+		Resource: "overlappingrangeipreservations",
+	}
+)
+
+func init() {
+	prometheus.MustRegister(orphansDetectedTotal, orphansReleasedTotal, reconcileErrorsTotal)
+}
+
+// Controller is the long-running, informer-driven replacement for
+// repeatedly cron-triggering ReconcileLooper. It keeps a live podref index
+// from the pod informer's add/update/delete events instead of calling
+// ListPods() on every reconcile, and watches
+// IPPool/OverlappingRangeIPReservation changes directly instead of only
+// reacting to pod deletes. A pod-delete event, once its grace period
+// elapses, only reconciles that one podref's allocations; a pool-side
+// change still reconciles every pool, since it isn't attributable to a
+// single podref. The one-shot ReconcileIPPools entrypoint on
+// ReconcileLooper keeps working unchanged for cron users; Controller is the
+// recommended mode for everyone else, and is safe to run with multiple
+// replicas behind Lease-based leader election.
+type Controller struct {
+	kubeConfigPath string
+	gracePeriod    time.Duration
+	leaseNamespace string
+	leaseIdentity  string
+	queue          workqueue.RateLimitingInterface
+
+	podRefsMu sync.RWMutex
+	podRefs   map[string]struct{}
+}
+
+// NewController builds a Controller backed by the kubeconfig at
+// kubeConfigPath, holding the coordination.k8s.io/Lease named
+// "whereabouts-reconciler" in leaseNamespace under leaseIdentity. The
+// orphan grace period is read from OrphanGracePeriodEnvVar, falling back
+// to defaultOrphanGracePeriod.
+func NewController(kubeConfigPath, leaseNamespace, leaseIdentity string) *Controller {
+	return &Controller{
+		kubeConfigPath: kubeConfigPath,
+		gracePeriod:    orphanGracePeriod(),
+		leaseNamespace: leaseNamespace,
+		leaseIdentity:  leaseIdentity,
+		queue:          workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		podRefs:        make(map[string]struct{}),
+	}
+}
+
+func orphanGracePeriod() time.Duration {
+	raw := os.Getenv(OrphanGracePeriodEnvVar)
+	if raw == "" {
+		return defaultOrphanGracePeriod
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil {
+		_ = logging.Errorf("invalid %s value %q, using the default of %s: %v", OrphanGracePeriodEnvVar, raw, defaultOrphanGracePeriod, err)
+		return defaultOrphanGracePeriod
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Run blocks running leader election; once this replica becomes leader it
+// runs the watch loop until ctx is canceled or leadership is lost.
+func (c *Controller) Run(ctx context.Context) error {
+	k8sClient, err := kubernetes.NewClient(c.kubeConfigPath)
+	if err != nil {
+		return logging.Errorf("failed to instantiate the Kubernetes client: %+v", err)
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", c.kubeConfigPath)
+	if err != nil {
+		return logging.Errorf("failed to build a REST config from %s: %v", c.kubeConfigPath, err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return logging.Errorf("failed to build a dynamic client: %v", err)
+	}
+
+	lock, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		c.leaseNamespace,
+		leaseName,
+		k8sClient.CoreV1Client(),
+		k8sClient.CoordinationV1Client(),
+		resourcelock.ResourceLockConfig{Identity: c.leaseIdentity},
+	)
+	if err != nil {
+		return logging.Errorf("failed to build the leader-election lock: %v", err)
+	}
+
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				if err := c.runWatchLoop(ctx, *k8sClient, dynamicClient); err != nil {
+					_ = logging.Errorf("controller watch loop exited: %v", err)
+				}
+			},
+			OnStoppedLeading: func() {
+				logging.Debugf("%s stopped leading the whereabouts-reconciler lease", c.leaseIdentity)
+			},
+		},
+	})
+
+	return nil
+}
+
+// runWatchLoop watches pods to keep a live podref index and to enqueue
+// deleted podrefs for reconciliation after gracePeriod, and watches
+// IPPool/OverlappingRangeIPReservation changes to enqueue a full reconcile
+// pass whenever a pool itself moves, instead of sweeping every pod and pool
+// on a timer.
+func (c *Controller) runWatchLoop(ctx context.Context, k8sClient kubernetes.Client, dynamicClient dynamic.Interface) error {
+	podInformer := k8sClient.NewPodInformer(ctx)
+	if _, err := podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.trackPodRef,
+		UpdateFunc: func(_, newObj interface{}) { c.trackPodRef(newObj) },
+		DeleteFunc: func(obj interface{}) {
+			podRef, ok := kubernetes.PodRefFromObject(obj)
+			if !ok {
+				return
+			}
+			c.forgetPodRef(podRef)
+			time.AfterFunc(c.gracePeriod, func() {
+				c.queue.Add(podRef)
+			})
+		},
+	}); err != nil {
+		return logging.Errorf("failed to register the pod event handler: %v", err)
+	}
+
+	poolInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0)
+	poolHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { c.queue.Add(poolResyncKey) },
+		UpdateFunc: func(interface{}, interface{}) { c.queue.Add(poolResyncKey) },
+		DeleteFunc: func(interface{}) { c.queue.Add(poolResyncKey) },
+	}
+	ippoolInformer := poolInformerFactory.ForResource(ippoolGVR).Informer()
+	if _, err := ippoolInformer.AddEventHandler(poolHandler); err != nil {
+		return logging.Errorf("failed to register the IPPool event handler: %v", err)
+	}
+	overlappingInformer := poolInformerFactory.ForResource(overlappingRangeIPReservationGVR).Informer()
+	if _, err := overlappingInformer.AddEventHandler(poolHandler); err != nil {
+		return logging.Errorf("failed to register the OverlappingRangeIPReservation event handler: %v", err)
+	}
+
+	go podInformer.Run(ctx.Done())
+	poolInformerFactory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), podInformer.HasSynced, ippoolInformer.HasSynced, overlappingInformer.HasSynced) {
+		return fmt.Errorf("timed out waiting for the controller's informer caches to sync")
+	}
+
+	go func() {
+		<-ctx.Done()
+		c.queue.ShutDown()
+	}()
+	for c.processNextItem(ctx, k8sClient) {
+	}
+	return nil
+}
+
+func (c *Controller) trackPodRef(obj interface{}) {
+	podRef, ok := kubernetes.PodRefFromObject(obj)
+	if !ok {
+		return
+	}
+	c.podRefsMu.Lock()
+	defer c.podRefsMu.Unlock()
+	c.podRefs[podRef] = struct{}{}
+}
+
+func (c *Controller) forgetPodRef(podRef string) {
+	c.podRefsMu.Lock()
+	defer c.podRefsMu.Unlock()
+	delete(c.podRefs, podRef)
+}
+
+// livePodRefs snapshots the podref index built from the pod informer, so a
+// reconcile pass doesn't need to call ListPods()/the PodResources API
+// itself and race the very event that triggered it.
+func (c *Controller) livePodRefs() []string {
+	c.podRefsMu.RLock()
+	defer c.podRefsMu.RUnlock()
+	refs := make([]string, 0, len(c.podRefs))
+	for podRef := range c.podRefs {
+		refs = append(refs, podRef)
+	}
+	return refs
+}
+
+// processNextItem reconciles the work item at the head of the queue. A
+// podref item (from a pod-delete event) only considers that one podref's
+// allocations across all pools, rather than re-evaluating every orphan in
+// the cluster on every single pod delete. The poolResyncKey item (from an
+// IPPool/OverlappingRangeIPReservation change) still reconciles every pool,
+// since a pool-side edit isn't attributable to one podref, but it uses the
+// controller's already-known live podref index instead of re-listing pods.
+func (c *Controller) processNextItem(ctx context.Context, k8sClient kubernetes.Client) bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	podRef, _ := key.(string)
+
+	var (
+		looper *ReconcileLooper
+		err    error
+	)
+	if podRef == poolResyncKey {
+		looper, err = NewReconcileLooperWithPodRefs(ctx, k8sClient, c.livePodRefs())
+	} else {
+		looper, err = NewReconcileLooperForPodRef(ctx, k8sClient, podRef, c.livePodRefs())
+	}
+	if err != nil {
+		reconcileErrorsTotal.Inc()
+		_ = logging.Errorf("failed to build a reconcile looper while handling %v: %v", key, err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	orphanCount := 0
+	for _, orphanedIP := range looper.orphanedIPs {
+		orphanCount += len(orphanedIP.Allocations)
+	}
+	cleanedUp, err := looper.ReconcileIPPools()
+	if err != nil {
+		reconcileErrorsTotal.Inc()
+		_ = logging.Errorf("failed to reconcile IP pools while handling %v: %v", key, err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	orphansDetectedTotal.Add(float64(orphanCount))
+	orphansReleasedTotal.Add(float64(len(cleanedUp)))
+	c.queue.Forget(key)
+	return true
+}
@@ -3,6 +3,7 @@ package reconciler
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/dougbtv/whereabouts/pkg/allocate"
 	"github.com/dougbtv/whereabouts/pkg/logging"
@@ -11,13 +12,28 @@ import (
 	"github.com/dougbtv/whereabouts/pkg/types"
 )
 
+// PodResourcesSocketEnvVar lets operators point the reconciler at the
+// kubelet PodResources socket, opting into the gRPC-backed orphan
+// detection instead of the default API-server pod list. An empty value
+// (the default) keeps the existing ListPods()-based behavior.
+const PodResourcesSocketEnvVar = "WHEREABOUTS_PODRESOURCES_SOCKET"
+
 type ReconcileLooper struct {
 	ctx         context.Context
-	k8sClient   kubernetes.Client
+	k8sClient   reconcilerClient
 	livePodRefs []string
+	podDevices  kubernetes.PodDevices
 	orphanedIPs []OrphanedIPReservations
 }
 
+// reconcilerClient is the subset of kubernetes.Client the reconciler
+// depends on, kept as a narrow interface so tests can stub it out without a
+// live API server.
+type reconcilerClient interface {
+	ListIPPools(ctx context.Context) ([]storage.IPPool, error)
+	TopOwnerGone(ctx context.Context, owner types.OwnerReference) (bool, error)
+}
+
 type OrphanedIPReservations struct {
 	Pool        storage.IPPool
 	Allocations []types.IPReservation
@@ -31,7 +47,7 @@ func NewReconcileLooper(kubeConfigPath string, ctx context.Context) (*ReconcileL
 	}
 	logging.Debugf("successfully read the kubernetes configuration file located at: %s", kubeConfigPath)
 
-	podRefs, err := getPodRefs(*k8sClient)
+	podRefs, podDevices, err := getPodRefs(ctx, *k8sClient)
 	if err != nil {
 		return nil, err
 	}
@@ -40,6 +56,7 @@ func NewReconcileLooper(kubeConfigPath string, ctx context.Context) (*ReconcileL
 		ctx:         ctx,
 		k8sClient:   *k8sClient,
 		livePodRefs: podRefs,
+		podDevices:  podDevices,
 	}
 
 	if err := looper.findOrphanedIPsPerPool(); err != nil {
@@ -48,20 +65,96 @@ func NewReconcileLooper(kubeConfigPath string, ctx context.Context) (*ReconcileL
 	return looper, nil
 }
 
-func getPodRefs(k8sClient kubernetes.Client) ([]string, error) {
+// NewReconcileLooperWithPodRefs builds a ReconcileLooper the same way
+// NewReconcileLooper does, except it takes the live podref set directly
+// instead of calling ListPods()/the PodResources API itself. Controller
+// already maintains this set from its pod informer, so reusing it here
+// avoids a second, potentially racy listing on every reconcile.
+func NewReconcileLooperWithPodRefs(ctx context.Context, k8sClient kubernetes.Client, livePodRefs []string) (*ReconcileLooper, error) {
+	looper := &ReconcileLooper{
+		ctx:         ctx,
+		k8sClient:   k8sClient,
+		livePodRefs: livePodRefs,
+	}
+	if err := looper.findOrphanedIPsPerPool(); err != nil {
+		return nil, err
+	}
+	return looper, nil
+}
+
+// NewReconcileLooperForPodRef builds a ReconcileLooper scoped to a single
+// triggering podRef instead of every allocation in the cluster: only
+// podRef's own reservations are considered for orphan status, so a single
+// pod-delete event can't release an unrelated allocation that happened to
+// already be orphaned for some other reason. livePodRefs is the
+// controller's already-known live podref index, reused for the same reason
+// as NewReconcileLooperWithPodRefs.
+func NewReconcileLooperForPodRef(ctx context.Context, k8sClient kubernetes.Client, podRef string, livePodRefs []string) (*ReconcileLooper, error) {
+	looper := &ReconcileLooper{
+		ctx:         ctx,
+		k8sClient:   k8sClient,
+		livePodRefs: livePodRefs,
+	}
+	if err := looper.findOrphanedIPForPodRef(podRef); err != nil {
+		return nil, err
+	}
+	return looper, nil
+}
+
+// getPodRefs builds the live podref set. When WHEREABOUTS_PODRESOURCES_SOCKET
+// is set and reachable, it is sourced from kubelet's PodResources API, which
+// reflects kubelet's own bookkeeping rather than the (potentially racy)
+// API-server pod list, and additionally yields a podref -> deviceIDs map.
+// Otherwise it falls back to the existing ListPods() approach.
+func getPodRefs(ctx context.Context, k8sClient kubernetes.Client) ([]string, kubernetes.PodDevices, error) {
+	if socketPath := os.Getenv(PodResourcesSocketEnvVar); socketPath != "" {
+		podRefs, podDevices, err := getPodRefsFromPodResources(ctx, socketPath)
+		if err != nil {
+			logging.Errorf("falling back to the Kubernetes pod list: %v", err)
+		} else {
+			return podRefs, podDevices, nil
+		}
+	}
+
 	pods, err := k8sClient.ListPods()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var podRefs []string
 	for _, pod := range pods {
 		podRefs = append(podRefs, fmt.Sprintf("%s/%s", pod.GetNamespace(), pod.GetName()))
 	}
-	return podRefs, err
+	return podRefs, nil, nil
+}
+
+func getPodRefsFromPodResources(ctx context.Context, socketPath string) ([]string, kubernetes.PodDevices, error) {
+	podResourcesClient, err := kubernetes.NewPodResourcesClient(socketPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer podResourcesClient.Close()
+
+	return podResourcesClient.ListPodRefs(ctx)
 }
 
+// findOrphanedIPsPerPool considers every allocation in every pool for
+// orphan status.
 func (rl *ReconcileLooper) findOrphanedIPsPerPool() error {
+	return rl.findOrphans(func(allocation types.IPReservation) bool { return true })
+}
+
+// findOrphanedIPForPodRef considers only podRef's own allocations for
+// orphan status, leaving every other allocation in every pool untouched.
+// Whereabouts keeps no pool-by-podref index, so this still has to look at
+// every pool's allocation list once, but what it can release is limited to
+// podRef - the podref a single triggering event actually concerns - instead
+// of re-evaluating every orphan already accumulated in the cluster.
+func (rl *ReconcileLooper) findOrphanedIPForPodRef(podRef string) error {
+	return rl.findOrphans(func(allocation types.IPReservation) bool { return allocation.PodRef == podRef })
+}
+
+func (rl *ReconcileLooper) findOrphans(consider func(types.IPReservation) bool) error {
 	ipPools, err := rl.k8sClient.ListIPPools(rl.ctx)
 	if err != nil {
 		return logging.Errorf("failed to retrieve all IP pools: %v", err)
@@ -72,15 +165,24 @@ func (rl *ReconcileLooper) findOrphanedIPsPerPool() error {
 			Pool: pool,
 		}
 		for _, allocation := range pool.Allocations() {
+			if !consider(allocation) {
+				continue
+			}
 			logging.Debugf("the IP reservation: %s", allocation)
 			if allocation.PodRef == "" {
 				_ = logging.Errorf("pod ref missing for Allocations: %s", allocation)
 				continue
 			}
-			if !rl.isPodAlive(allocation.PodRef) {
-				logging.Debugf("pod ref %s is not listed in the live pods list", allocation.PodRef)
-				orphanIP.Allocations = append(orphanIP.Allocations, allocation)
+			orphan, err := rl.isOrphan(allocation)
+			if err != nil {
+				_ = logging.Errorf("failed to determine whether %s is orphaned, leaving the reservation in place: %v", allocation.PodRef, err)
+				continue
+			}
+			if !orphan {
+				continue
 			}
+			logging.Debugf("pod ref %s is not listed in the live pods list", allocation.PodRef)
+			orphanIP.Allocations = append(orphanIP.Allocations, allocation)
 		}
 		if len(orphanIP.Allocations) > 0 {
 			rl.orphanedIPs = append(rl.orphanedIPs, orphanIP)
@@ -90,6 +192,57 @@ func (rl *ReconcileLooper) findOrphanedIPsPerPool() error {
 	return nil
 }
 
+// isOrphan reports whether allocation should be garbage collected, honoring
+// its release policy: a ReleasePolicyNever allocation is never orphaned, a
+// live pod (or one whose device kubelet still holds) is never orphaned,
+// and a ReleasePolicyImmutable allocation is only orphaned once its owning
+// workload - not just the individual pod - is gone.
+func (rl ReconcileLooper) isOrphan(allocation types.IPReservation) (bool, error) {
+	if allocation.Policy == types.ReleasePolicyNever {
+		logging.Debugf("pod ref %s is reserved forever by its release policy, skipping", allocation.PodRef)
+		return false, nil
+	}
+	if rl.isPodAlive(allocation.PodRef) {
+		return false, nil
+	}
+	if rl.podDevices != nil && allocation.DeviceID != "" && rl.podDevices.HoldsDevice(allocation.PodRef, allocation.DeviceID) {
+		logging.Debugf("pod ref %s is gone but kubelet still holds device %s for it", allocation.PodRef, allocation.DeviceID)
+		return false, nil
+	}
+	if allocation.Policy == types.ReleasePolicyImmutable {
+		gone, err := rl.k8sClient.TopOwnerGone(rl.ctx, allocation.OwnerRef)
+		if err != nil {
+			return false, err
+		}
+		if !gone {
+			logging.Debugf("pod ref %s is gone but its owning workload is still around, immutable reservation stays", allocation.PodRef)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// OrphanedIPsByPf groups the orphaned allocations found by
+// findOrphanedIPsPerPool by their originating SR-IOV PF, so pool
+// exhaustion metrics/events can be attributed to a specific uplink.
+// Allocations are grouped by the PfName the allocator already resolved and
+// stamped on them via kubernetes.ResolveAllocationPf at allocation time;
+// anything without one (non-SR-IOV allocations, or reservations made before
+// this field existed) is grouped under the empty string. This intentionally
+// does not attempt to re-resolve a PF from allocation.DeviceID here: that
+// field isn't guaranteed to be a PCI address once deviceID stops being
+// sourced from CNI_ARGS, so re-resolving it this far from allocation time
+// risks feeding kubernetes.GetPfName a value it was never meant to parse.
+func (rl ReconcileLooper) OrphanedIPsByPf() map[string][]types.IPReservation {
+	byPf := make(map[string][]types.IPReservation)
+	for _, orphanedIP := range rl.orphanedIPs {
+		for _, allocation := range orphanedIP.Allocations {
+			byPf[allocation.PfName] = append(byPf[allocation.PfName], allocation)
+		}
+	}
+	return byPf
+}
+
 func (rl ReconcileLooper) isPodAlive(podRef string) bool {
 	for _, livePodRef := range rl.livePodRefs {
 		if podRef == livePodRef {
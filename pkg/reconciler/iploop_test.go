@@ -0,0 +1,179 @@
+package reconciler
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/dougbtv/whereabouts/pkg/storage"
+	"github.com/dougbtv/whereabouts/pkg/types"
+)
+
+// fakeReconcilerClient stubs the reconcilerClient interface so
+// findOrphanedIPsPerPool can be exercised without a live API server.
+type fakeReconcilerClient struct {
+	pools          []*fakePool
+	topOwnerGoneBy map[types.OwnerReference]bool
+}
+
+func (f *fakeReconcilerClient) ListIPPools(context.Context) ([]storage.IPPool, error) {
+	pools := make([]storage.IPPool, 0, len(f.pools))
+	for _, p := range f.pools {
+		pools = append(pools, p)
+	}
+	return pools, nil
+}
+
+func (f *fakeReconcilerClient) TopOwnerGone(_ context.Context, owner types.OwnerReference) (bool, error) {
+	return f.topOwnerGoneBy[owner], nil
+}
+
+// fakePool implements storage.IPPool backed by an in-memory reservation
+// list, recording whatever ReconcileIPPools last wrote back via Update.
+type fakePool struct {
+	allocations []types.IPReservation
+	updated     []types.IPReservation
+}
+
+func (p *fakePool) Allocations() []types.IPReservation { return p.allocations }
+
+func (p *fakePool) Update(_ context.Context, reservations []types.IPReservation) error {
+	p.updated = reservations
+	return nil
+}
+
+func reservation(podRef string, policy types.ReleasePolicy, lastOctet byte) types.IPReservation {
+	return types.IPReservation{
+		IP:     net.IPv4(192, 168, 1, lastOctet),
+		PodRef: podRef,
+		Policy: policy,
+	}
+}
+
+// immutableReservation is reservation for a ReleasePolicyImmutable
+// allocation, additionally stamped with the owner reference
+// TestFindOrphanedIPsPerPoolMixedPolicies' fakeReconcilerClient keys its
+// topOwnerGoneBy answers on.
+func immutableReservation(podRef string, owner types.OwnerReference, lastOctet byte) types.IPReservation {
+	r := reservation(podRef, types.ReleasePolicyImmutable, lastOctet)
+	r.OwnerRef = owner
+	return r
+}
+
+func TestFindOrphanedIPsPerPoolMixedPolicies(t *testing.T) {
+	ownerAlive := types.OwnerReference{Namespace: "default", Kind: "StatefulSet", Name: "alive"}
+	ownerGone := types.OwnerReference{Namespace: "default", Kind: "StatefulSet", Name: "gone"}
+
+	pool := &fakePool{
+		allocations: []types.IPReservation{
+			reservation("default/never-released", types.ReleasePolicyNever, 1),
+			immutableReservation("default/immutable-owner-alive", ownerAlive, 2),
+			immutableReservation("default/immutable-owner-gone", ownerGone, 3),
+			reservation("default/always-release-dead", types.ReleasePolicyAlwaysRelease, 4),
+			reservation("default/always-release-live", types.ReleasePolicyAlwaysRelease, 5),
+		},
+	}
+
+	rl := &ReconcileLooper{
+		ctx: context.Background(),
+		k8sClient: &fakeReconcilerClient{
+			pools: []*fakePool{pool},
+			topOwnerGoneBy: map[types.OwnerReference]bool{
+				ownerAlive: false,
+				ownerGone:  true,
+			},
+		},
+		livePodRefs: []string{"default/always-release-live"},
+	}
+
+	if err := rl.findOrphanedIPsPerPool(); err != nil {
+		t.Fatalf("findOrphanedIPsPerPool: %v", err)
+	}
+
+	if len(rl.orphanedIPs) != 1 {
+		t.Fatalf("got %d orphaned pools, want 1", len(rl.orphanedIPs))
+	}
+
+	var gotPodRefs []string
+	for _, allocation := range rl.orphanedIPs[0].Allocations {
+		gotPodRefs = append(gotPodRefs, allocation.PodRef)
+	}
+	wantPodRefs := []string{"default/immutable-owner-gone", "default/always-release-dead"}
+	if len(gotPodRefs) != len(wantPodRefs) {
+		t.Fatalf("got orphaned podrefs %v, want %v", gotPodRefs, wantPodRefs)
+	}
+	for i, want := range wantPodRefs {
+		if gotPodRefs[i] != want {
+			t.Fatalf("got orphaned podrefs %v, want %v", gotPodRefs, wantPodRefs)
+		}
+	}
+
+	cleanedUp, err := rl.ReconcileIPPools()
+	if err != nil {
+		t.Fatalf("ReconcileIPPools: %v", err)
+	}
+	if len(cleanedUp) != 2 {
+		t.Fatalf("got %d cleaned up reservations, want 2", len(cleanedUp))
+	}
+
+	remaining := make(map[string]bool)
+	for _, allocation := range pool.updated {
+		remaining[allocation.PodRef] = true
+	}
+	for _, podRef := range []string{"default/never-released", "default/immutable-owner-alive", "default/always-release-live"} {
+		if !remaining[podRef] {
+			t.Fatalf("expected %s to remain allocated, got %v", podRef, pool.updated)
+		}
+	}
+	for _, podRef := range wantPodRefs {
+		if remaining[podRef] {
+			t.Fatalf("expected %s to be released, got %v", podRef, pool.updated)
+		}
+	}
+}
+
+func TestFindOrphanedIPForPodRefScopesToOnePodRef(t *testing.T) {
+	pool := &fakePool{
+		allocations: []types.IPReservation{
+			reservation("default/triggering-delete", types.ReleasePolicyAlwaysRelease, 1),
+			reservation("default/other-orphan", types.ReleasePolicyAlwaysRelease, 2),
+		},
+	}
+
+	rl := &ReconcileLooper{
+		ctx: context.Background(),
+		k8sClient: &fakeReconcilerClient{
+			pools: []*fakePool{pool},
+		},
+		livePodRefs: nil,
+	}
+
+	if err := rl.findOrphanedIPForPodRef("default/triggering-delete"); err != nil {
+		t.Fatalf("findOrphanedIPForPodRef: %v", err)
+	}
+
+	if len(rl.orphanedIPs) != 1 {
+		t.Fatalf("got %d orphaned pools, want 1", len(rl.orphanedIPs))
+	}
+	if len(rl.orphanedIPs[0].Allocations) != 1 {
+		t.Fatalf("got %d orphaned allocations, want 1", len(rl.orphanedIPs[0].Allocations))
+	}
+	if got := rl.orphanedIPs[0].Allocations[0].PodRef; got != "default/triggering-delete" {
+		t.Fatalf("got orphaned podref %q, want %q", got, "default/triggering-delete")
+	}
+
+	if _, err := rl.ReconcileIPPools(); err != nil {
+		t.Fatalf("ReconcileIPPools: %v", err)
+	}
+
+	remaining := make(map[string]bool)
+	for _, allocation := range pool.updated {
+		remaining[allocation.PodRef] = true
+	}
+	if remaining["default/triggering-delete"] {
+		t.Fatalf("expected default/triggering-delete to be released, got %v", pool.updated)
+	}
+	if !remaining["default/other-orphan"] {
+		t.Fatalf("expected default/other-orphan to be left alone by an unrelated podref's reconcile, got %v", pool.updated)
+	}
+}
@@ -0,0 +1,53 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dougbtv/whereabouts/pkg/types"
+)
+
+// NewIPReservation builds the IPReservation the CNI ADD path should persist
+// for a newly allocated ip against the already-selected pool (see
+// SelectPoolForPf for SR-IOV pools), resolving podRef's release policy,
+// controller owner, and SR-IOV PF in a single Get instead of the three
+// separate, independently-Getting helpers this used to be split across.
+// configPolicy is the IPAM config's release_policy key; sriovCfg is the
+// pool's resourceName/PCI-address pairing for this allocation (zero value
+// for a non-SR-IOV pool).
+func (c Client) NewIPReservation(ctx context.Context, podRef string, ip net.IP, configPolicy string, sriovCfg types.SriovAllocationConfig) (types.IPReservation, error) {
+	namespace, name, err := splitPodRef(podRef)
+	if err != nil {
+		return types.IPReservation{}, err
+	}
+
+	pod, err := c.clientSet.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return types.IPReservation{}, fmt.Errorf("error getting pod %s: %v", podRef, err)
+	}
+
+	policy, err := types.ResolveReleasePolicy(pod.Annotations, configPolicy)
+	if err != nil {
+		return types.IPReservation{}, err
+	}
+
+	owner, _ := ControllerOwnerRef(namespace, pod.OwnerReferences)
+
+	pfName, err := ResolveAllocationPf(sriovCfg)
+	if err != nil {
+		return types.IPReservation{}, err
+	}
+
+	return types.IPReservation{
+		IP:          ip,
+		PodRef:      podRef,
+		IsAllocated: true,
+		PfName:      pfName,
+		DeviceID:    sriovCfg.PciDeviceID,
+		Policy:      policy,
+		OwnerRef:    owner,
+	}, nil
+}
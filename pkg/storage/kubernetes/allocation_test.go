@@ -0,0 +1,65 @@
+package kubernetes
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/dougbtv/whereabouts/pkg/types"
+)
+
+func TestNewIPReservationStampsPolicyAndOwner(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "web-0",
+			Annotations: map[string]string{types.ReleasePolicyAnnotation: "Immutable"},
+			OwnerReferences: []metav1.OwnerReference{
+				controllerRef("StatefulSet", "web"),
+			},
+		},
+	}
+	c := Client{clientSet: fake.NewSimpleClientset(pod)}
+
+	ip := net.IPv4(192, 168, 1, 5)
+	reservation, err := c.NewIPReservation(context.Background(), "default/web-0", ip, "", types.SriovAllocationConfig{})
+	if err != nil {
+		t.Fatalf("NewIPReservation: %v", err)
+	}
+
+	if reservation.Policy != types.ReleasePolicyImmutable {
+		t.Fatalf("got policy %q, want %q", reservation.Policy, types.ReleasePolicyImmutable)
+	}
+	wantOwner := types.OwnerReference{Namespace: "default", Kind: "StatefulSet", Name: "web"}
+	if reservation.OwnerRef != wantOwner {
+		t.Fatalf("got owner %+v, want %+v", reservation.OwnerRef, wantOwner)
+	}
+	if !reservation.IP.Equal(ip) {
+		t.Fatalf("got IP %s, want %s", reservation.IP, ip)
+	}
+	if reservation.PodRef != "default/web-0" {
+		t.Fatalf("got podref %q, want %q", reservation.PodRef, "default/web-0")
+	}
+}
+
+func TestNewIPReservationFallsBackToConfigPolicy(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "plain"},
+	}
+	c := Client{clientSet: fake.NewSimpleClientset(pod)}
+
+	reservation, err := c.NewIPReservation(context.Background(), "default/plain", net.IPv4(192, 168, 1, 6), "Never", types.SriovAllocationConfig{})
+	if err != nil {
+		t.Fatalf("NewIPReservation: %v", err)
+	}
+	if reservation.Policy != types.ReleasePolicyNever {
+		t.Fatalf("got policy %q, want %q", reservation.Policy, types.ReleasePolicyNever)
+	}
+	if reservation.OwnerRef != (types.OwnerReference{}) {
+		t.Fatalf("got owner %+v, want zero value for a pod with no controller owner", reservation.OwnerRef)
+	}
+}
@@ -0,0 +1,50 @@
+package kubernetes
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// CoreV1Client exposes the underlying CoreV1 client, e.g. for building a
+// leader-election lock.
+func (c Client) CoreV1Client() corev1client.CoreV1Interface {
+	return c.clientSet.CoreV1()
+}
+
+// CoordinationV1Client exposes the underlying CoordinationV1 client, used
+// to back a coordination.k8s.io/Lease leader-election lock.
+func (c Client) CoordinationV1Client() coordinationv1client.CoordinationV1Interface {
+	return c.clientSet.CoordinationV1()
+}
+
+// NewPodInformer returns a SharedIndexInformer watching all pods. The
+// controller-style reconciler uses it to react to pod deletions as they
+// happen instead of polling the API server with ListPods() on a timer.
+func (c Client) NewPodInformer(ctx context.Context) cache.SharedIndexInformer {
+	factory := informers.NewSharedInformerFactory(c.clientSet, 0)
+	return factory.Core().V1().Pods().Informer()
+}
+
+// PodRefFromObject extracts the "namespace/name" podref Whereabouts stores
+// in its IP reservations from a pod informer event object, unwrapping a
+// cache.DeletedFinalStateUnknown tombstone when the delete event arrived
+// after a watch resync.
+func PodRefFromObject(obj interface{}) (string, bool) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			return "", false
+		}
+		pod, ok = tombstone.Obj.(*corev1.Pod)
+		if !ok {
+			return "", false
+		}
+	}
+	return pod.GetNamespace() + "/" + pod.GetName(), true
+}
@@ -0,0 +1,91 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dougbtv/whereabouts/pkg/types"
+)
+
+// TopOwnerGone walks owner up the controller chain (e.g. a ReplicaSet
+// sitting under a Deployment) and reports whether the top controller has
+// since been deleted. owner is captured once at allocation time via
+// ControllerOwnerRef, while the pod still exists: by the time the
+// reconciler asks this question the pod itself is already gone, so this
+// must not depend on fetching the pod again. A zero-value owner (a pod
+// with no controller owner) is reported gone immediately, since there's
+// nothing left to keep the reservation alive for.
+func (c Client) TopOwnerGone(ctx context.Context, owner types.OwnerReference) (bool, error) {
+	if owner == (types.OwnerReference{}) {
+		return true, nil
+	}
+
+	ref := metav1.OwnerReference{Kind: owner.Kind, Name: owner.Name}
+	for {
+		ownerMeta, err := c.getControllerObject(ctx, owner.Namespace, ref)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		}
+		next := findControllerRef(ownerMeta.GetOwnerReferences())
+		if next == nil {
+			return false, nil
+		}
+		ref = *next
+	}
+}
+
+// ControllerOwnerRef extracts the controller owner reference from a pod's
+// ownerReferences, for the allocation path to capture and stamp onto the
+// IPReservation it's about to create. Returns (OwnerReference{}, false) if
+// the pod has no controller owner.
+func ControllerOwnerRef(namespace string, refs []metav1.OwnerReference) (types.OwnerReference, bool) {
+	owner := findControllerRef(refs)
+	if owner == nil {
+		return types.OwnerReference{}, false
+	}
+	return types.OwnerReference{Namespace: namespace, Kind: owner.Kind, Name: owner.Name}, true
+}
+
+// getControllerObject fetches the object referenced by ref, for the small
+// set of controller kinds Whereabouts needs to walk through on the way to
+// the top owner.
+func (c Client) getControllerObject(ctx context.Context, namespace string, ref metav1.OwnerReference) (metav1.Object, error) {
+	switch ref.Kind {
+	case "StatefulSet":
+		return c.clientSet.AppsV1().StatefulSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "ReplicaSet":
+		return c.clientSet.AppsV1().ReplicaSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "Deployment":
+		return c.clientSet.AppsV1().Deployments(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "DaemonSet":
+		return c.clientSet.AppsV1().DaemonSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "Job":
+		return c.clientSet.BatchV1().Jobs(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	default:
+		return nil, fmt.Errorf("unsupported owner kind %q for %s/%s", ref.Kind, namespace, ref.Name)
+	}
+}
+
+func findControllerRef(refs []metav1.OwnerReference) *metav1.OwnerReference {
+	for i := range refs {
+		if refs[i].Controller != nil && *refs[i].Controller {
+			return &refs[i]
+		}
+	}
+	return nil
+}
+
+func splitPodRef(podRef string) (namespace, name string, err error) {
+	parts := strings.SplitN(podRef, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid pod ref %q, expected namespace/name", podRef)
+	}
+	return parts[0], parts[1], nil
+}
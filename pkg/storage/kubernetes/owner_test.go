@@ -0,0 +1,105 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/dougbtv/whereabouts/pkg/types"
+)
+
+func controllerRef(kind, name string) metav1.OwnerReference {
+	t := true
+	return metav1.OwnerReference{Kind: kind, Name: name, Controller: &t}
+}
+
+// TestTopOwnerGoneStatefulSetStillAround exercises the case Immutable
+// exists for: the pod is already deleted (as it always is by the time
+// TopOwnerGone is called), but its StatefulSet is still around. No Pod
+// object is ever created in the fake clientset, proving TopOwnerGone
+// doesn't need to fetch the pod to answer this.
+func TestTopOwnerGoneStatefulSetStillAround(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+	})
+	c := Client{clientSet: clientset}
+
+	owner := types.OwnerReference{Namespace: "default", Kind: "StatefulSet", Name: "web"}
+	gone, err := c.TopOwnerGone(context.Background(), owner)
+	if err != nil {
+		t.Fatalf("TopOwnerGone: %v", err)
+	}
+	if gone {
+		t.Fatalf("got gone=true, want false: the StatefulSet still exists")
+	}
+}
+
+// TestTopOwnerGoneStatefulSetDeleted covers the StatefulSet having since
+// been deleted too, so the Immutable reservation should finally release.
+func TestTopOwnerGoneStatefulSetDeleted(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	c := Client{clientSet: clientset}
+
+	owner := types.OwnerReference{Namespace: "default", Kind: "StatefulSet", Name: "web"}
+	gone, err := c.TopOwnerGone(context.Background(), owner)
+	if err != nil {
+		t.Fatalf("TopOwnerGone: %v", err)
+	}
+	if !gone {
+		t.Fatalf("got gone=false, want true: the StatefulSet no longer exists")
+	}
+}
+
+// TestTopOwnerGoneWalksReplicaSetToDeployment covers a Deployment-managed
+// pod, whose immediate controller is a ReplicaSet, not the Deployment
+// itself: TopOwnerGone must walk past the still-existing ReplicaSet to
+// find that the Deployment above it is gone.
+func TestTopOwnerGoneWalksReplicaSetToDeployment(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "web-7d8f9c",
+			OwnerReferences: []metav1.OwnerReference{controllerRef("Deployment", "web")},
+		},
+	})
+	c := Client{clientSet: clientset}
+
+	owner := types.OwnerReference{Namespace: "default", Kind: "ReplicaSet", Name: "web-7d8f9c"}
+	gone, err := c.TopOwnerGone(context.Background(), owner)
+	if err != nil {
+		t.Fatalf("TopOwnerGone: %v", err)
+	}
+	if !gone {
+		t.Fatalf("got gone=false, want true: the Deployment above the ReplicaSet no longer exists")
+	}
+}
+
+func TestTopOwnerGoneNoOwner(t *testing.T) {
+	c := Client{clientSet: fake.NewSimpleClientset()}
+
+	gone, err := c.TopOwnerGone(context.Background(), types.OwnerReference{})
+	if err != nil {
+		t.Fatalf("TopOwnerGone: %v", err)
+	}
+	if !gone {
+		t.Fatalf("got gone=false, want true: a pod with no controller owner has nothing left to protect")
+	}
+}
+
+func TestControllerOwnerRef(t *testing.T) {
+	owner, ok := ControllerOwnerRef("default", []metav1.OwnerReference{controllerRef("StatefulSet", "web")})
+	if !ok {
+		t.Fatalf("expected a controller owner to be found")
+	}
+	want := types.OwnerReference{Namespace: "default", Kind: "StatefulSet", Name: "web"}
+	if owner != want {
+		t.Fatalf("got owner %+v, want %+v", owner, want)
+	}
+
+	if _, ok := ControllerOwnerRef("default", nil); ok {
+		t.Fatalf("expected no controller owner to be found for a pod with no owner references")
+	}
+}
@@ -0,0 +1,103 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1"
+
+	"github.com/dougbtv/whereabouts/pkg/logging"
+)
+
+const (
+	// DefaultPodResourcesSocket is the well-known location of the kubelet
+	// PodResources v1 gRPC socket.
+	DefaultPodResourcesSocket = "/var/lib/kubelet/pod-resources/kubelet.sock"
+	podResourcesDialTimeout   = 10 * time.Second
+)
+
+// PodDevices maps a "namespace/name" podref to the device IDs kubelet
+// reports as currently assigned to it, across all of its containers.
+type PodDevices map[string][]string
+
+// HoldsDevice reports whether kubelet still considers deviceID assigned to
+// podRef. The reconciler uses this to avoid releasing an IP allocation
+// whose backing device kubelet hasn't relinquished yet.
+func (p PodDevices) HoldsDevice(podRef, deviceID string) bool {
+	for _, id := range p[podRef] {
+		if id == deviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// PodResourcesClient talks to the kubelet PodResources v1 gRPC API, giving
+// an authoritative, device-aware view of which pods are actually running
+// on this node instead of relying solely on the API server's pod list.
+type PodResourcesClient struct {
+	conn   *grpc.ClientConn
+	client podresourcesapi.PodResourcesListerClient
+}
+
+// NewPodResourcesClient dials the kubelet PodResources socket at
+// socketPath, defaulting to DefaultPodResourcesSocket when empty. Callers
+// should treat a non-nil error as "the socket isn't reachable" and fall
+// back to the pod-list approach rather than failing outright.
+func NewPodResourcesClient(socketPath string) (*PodResourcesClient, error) {
+	if socketPath == "" {
+		socketPath = DefaultPodResourcesSocket
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), podResourcesDialTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, socketPath,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", addr)
+		}),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return nil, logging.Errorf("failed to dial kubelet PodResources socket %s: %v", socketPath, err)
+	}
+
+	return &PodResourcesClient{
+		conn:   conn,
+		client: podresourcesapi.NewPodResourcesListerClient(conn),
+	}, nil
+}
+
+// Close releases the underlying gRPC connection.
+func (p *PodResourcesClient) Close() error {
+	return p.conn.Close()
+}
+
+// ListPodRefs queries the kubelet PodResources API and returns the live
+// set of "namespace/name" podrefs, together with the devices kubelet
+// reports as held by each of them.
+func (p *PodResourcesClient) ListPodRefs(ctx context.Context) ([]string, PodDevices, error) {
+	resp, err := p.client.List(ctx, &podresourcesapi.ListPodResourcesRequest{})
+	if err != nil {
+		return nil, nil, logging.Errorf("failed to list kubelet pod resources: %v", err)
+	}
+
+	var podRefs []string
+	deviceIDs := make(PodDevices)
+	for _, pod := range resp.GetPodResources() {
+		podRef := fmt.Sprintf("%s/%s", pod.GetNamespace(), pod.GetName())
+		podRefs = append(podRefs, podRef)
+		for _, container := range pod.GetContainers() {
+			for _, device := range container.GetDevices() {
+				deviceIDs[podRef] = append(deviceIDs[podRef], device.GetDeviceIds()...)
+			}
+		}
+	}
+
+	return podRefs, deviceIDs, nil
+}
@@ -0,0 +1,96 @@
+package kubernetes
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1"
+)
+
+// fakePodResourcesServer implements podresourcesapi.PodResourcesListerServer
+// with a fixed, in-memory response, standing in for the kubelet socket.
+type fakePodResourcesServer struct {
+	podresourcesapi.UnimplementedPodResourcesListerServer
+	resp *podresourcesapi.ListPodResourcesResponse
+}
+
+func (f *fakePodResourcesServer) List(context.Context, *podresourcesapi.ListPodResourcesRequest) (*podresourcesapi.ListPodResourcesResponse, error) {
+	return f.resp, nil
+}
+
+func startFakePodResourcesServer(t *testing.T, resp *podresourcesapi.ListPodResourcesResponse) string {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "kubelet.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %v", socketPath, err)
+	}
+
+	server := grpc.NewServer()
+	podresourcesapi.RegisterPodResourcesListerServer(server, &fakePodResourcesServer{resp: resp})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return socketPath
+}
+
+func TestListPodRefs(t *testing.T) {
+	resp := &podresourcesapi.ListPodResourcesResponse{
+		PodResources: []*podresourcesapi.PodResources{
+			{
+				Namespace: "default",
+				Name:      "with-device",
+				Containers: []*podresourcesapi.ContainerResources{
+					{
+						Devices: []*podresourcesapi.ContainerDevices{
+							{DeviceIds: []string{"0000:3b:00.1"}},
+						},
+					},
+				},
+			},
+			{
+				Namespace: "default",
+				Name:      "without-device",
+			},
+		},
+	}
+	socketPath := startFakePodResourcesServer(t, resp)
+
+	client, err := NewPodResourcesClient(socketPath)
+	if err != nil {
+		t.Fatalf("NewPodResourcesClient: %v", err)
+	}
+	defer client.Close()
+
+	podRefs, podDevices, err := client.ListPodRefs(context.Background())
+	if err != nil {
+		t.Fatalf("ListPodRefs: %v", err)
+	}
+
+	wantRefs := []string{"default/with-device", "default/without-device"}
+	if len(podRefs) != len(wantRefs) {
+		t.Fatalf("got podRefs %v, want %v", podRefs, wantRefs)
+	}
+	for i, want := range wantRefs {
+		if podRefs[i] != want {
+			t.Fatalf("got podRefs %v, want %v", podRefs, wantRefs)
+		}
+	}
+
+	if !podDevices.HoldsDevice("default/with-device", "0000:3b:00.1") {
+		t.Fatalf("expected default/with-device to hold device 0000:3b:00.1")
+	}
+	if podDevices.HoldsDevice("default/with-device", "0000:3b:00.2") {
+		t.Fatalf("did not expect default/with-device to hold an unrelated device")
+	}
+	if podDevices.HoldsDevice("default/without-device", "0000:3b:00.1") {
+		t.Fatalf("did not expect default/without-device to hold any device")
+	}
+	if podDevices.HoldsDevice("default/unknown-pod", "0000:3b:00.1") {
+		t.Fatalf("did not expect an unknown pod to hold any device")
+	}
+}
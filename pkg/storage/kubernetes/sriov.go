@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/k8snetworkplumbingwg/sriovnet"
@@ -12,13 +13,43 @@ import (
 )
 
 var (
-	sysBusPci = "/sys/bus/pci/devices"
+	sysBusPci   = "/sys/bus/pci/devices"
+	sysClassNet = "/sys/class/net"
 )
 
 const (
 	eswitchModeSwitchdev = "switchdev"
 )
 
+// PfResolver resolves the uplink PF netdevice name for a VF's PCI address.
+// Vendors whose PF/VF representor topology doesn't fit the legacy
+// physfn/net symlink or the switchdev uplink-representor convention can
+// plug in their own lookup by registering a PfResolver for their PCI
+// vendor/device ID via RegisterPfResolver.
+type PfResolver interface {
+	GetPfName(pciAddr string) (string, error)
+}
+
+type pfResolverKey struct {
+	vendorID uint16
+	deviceID uint16
+}
+
+var pfResolvers = make(map[pfResolverKey]PfResolver)
+
+// RegisterPfResolver registers r as the PfResolver for PCI devices whose
+// vendor/device IDs (as read from /sys/bus/pci/devices/<addr>/{vendor,device})
+// match vendorID/deviceID. It is meant to be called from init() by built-in
+// resolvers in this package, or by external packages adding support for a
+// vendor Whereabouts doesn't ship.
+func RegisterPfResolver(vendorID, deviceID uint16, r PfResolver) {
+	pfResolvers[pfResolverKey{vendorID, deviceID}] = r
+}
+
+func init() {
+	RegisterPfResolver(yusurVendorID, yusurSmartNICDeviceID, yusurPfResolver{})
+}
+
 // GetPfName returns SRIOV PF name for the given VF
 // If device is not VF then it will return empty string
 func GetPfName(pciAddr string) (string, error) {
@@ -26,6 +57,20 @@ func GetPfName(pciAddr string) (string, error) {
 		return "", nil
 	}
 
+	if vendorID, deviceID, err := readPciIDs(pciAddr); err != nil {
+		klog.Infof("could not read PCI vendor/device for %s, using the default PF resolver: %v", pciAddr, err)
+	} else if resolver, ok := pfResolvers[pfResolverKey{vendorID, deviceID}]; ok {
+		return resolver.GetPfName(pciAddr)
+	}
+
+	return defaultPfResolver{}.GetPfName(pciAddr)
+}
+
+// defaultPfResolver handles the legacy physfn/net symlink and the
+// Mellanox-style switchdev uplink-representor lookup.
+type defaultPfResolver struct{}
+
+func (defaultPfResolver) GetPfName(pciAddr string) (string, error) {
 	pfEswitchMode, err := GetPfEswitchMode(pciAddr)
 	if pfEswitchMode == "" {
 		// If device doesn't support eswitch mode query or doesn't have sriov enabled,
@@ -57,6 +102,74 @@ func GetPfName(pciAddr string) (string, error) {
 	return "", fmt.Errorf("the PF name is not found for device %s", pciAddr)
 }
 
+const (
+	// yusurVendorID is Yusur Information Technology Co., Ltd.'s PCI vendor ID.
+	yusurVendorID uint16 = 0x1f46
+	// yusurSmartNICDeviceID is the PCI device ID of Yusur's smartNIC family.
+	yusurSmartNICDeviceID uint16 = 0x2100
+)
+
+// yusurPfResolver resolves the PF for a VF on Yusur smartNIC devices, which
+// expose their PF/VF representor topology under
+// /sys/class/net/<pf>/smart_nic/vf<N>/ rather than the legacy physfn/net
+// symlink or a switchdev uplink representor.
+type yusurPfResolver struct{}
+
+func (yusurPfResolver) GetPfName(pciAddr string) (string, error) {
+	ifaces, err := os.ReadDir(sysClassNet)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", sysClassNet, err)
+	}
+
+	for _, iface := range ifaces {
+		smartNicDir := filepath.Join(sysClassNet, iface.Name(), "smart_nic")
+		vfDirs, err := os.ReadDir(smartNicDir)
+		if err != nil {
+			continue
+		}
+
+		for _, vfDir := range vfDirs {
+			if !strings.HasPrefix(vfDir.Name(), "vf") {
+				continue
+			}
+			vfPciAddr, err := os.Readlink(filepath.Join(smartNicDir, vfDir.Name(), "device"))
+			if err != nil {
+				continue
+			}
+			if filepath.Base(vfPciAddr) == pciAddr {
+				return iface.Name(), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("the PF name is not found for smartNIC device %s", pciAddr)
+}
+
+// readPciIDs reads the PCI vendor and device IDs for pciAddr from sysfs.
+func readPciIDs(pciAddr string) (vendorID, deviceID uint16, err error) {
+	vendorID, err = readPciHexField(pciAddr, "vendor")
+	if err != nil {
+		return 0, 0, err
+	}
+	deviceID, err = readPciHexField(pciAddr, "device")
+	if err != nil {
+		return 0, 0, err
+	}
+	return vendorID, deviceID, nil
+}
+
+func readPciHexField(pciAddr, field string) (uint16, error) {
+	raw, err := os.ReadFile(filepath.Join(sysBusPci, pciAddr, field))
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(string(raw)), "0x"), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing PCI %s for device %s: %v", field, pciAddr, err)
+	}
+	return uint16(value), nil
+}
+
 // IsSriovVF check if a pci device has link to a PF
 func IsSriovVF(pciAddr string) bool {
 	totalVfFilePath := filepath.Join(sysBusPci, pciAddr, "physfn")
@@ -0,0 +1,18 @@
+package kubernetes
+
+import "github.com/dougbtv/whereabouts/pkg/types"
+
+// ResolveAllocationPf resolves the SR-IOV PF name backing cfg's VF, for the
+// CNI ADD path to stamp onto the IPReservation it's about to create.
+//
+// It deliberately takes cfg.PciDeviceID, the PCI address sourced from
+// CNI_ARGS/runtimeConfig, rather than a kubelet PodResources device ID: the
+// PodResources ID format is opaque and plugin-specific, so it isn't safe to
+// feed into GetPfName, which expects an actual PCI address. Returns ("",
+// nil) for a non-SR-IOV allocation, i.e. when cfg.PciDeviceID is empty.
+func ResolveAllocationPf(cfg types.SriovAllocationConfig) (string, error) {
+	if cfg.PciDeviceID == "" {
+		return "", nil
+	}
+	return GetPfName(cfg.PciDeviceID)
+}
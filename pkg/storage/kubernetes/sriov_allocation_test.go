@@ -0,0 +1,46 @@
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dougbtv/whereabouts/pkg/types"
+)
+
+func TestResolveAllocationPfNonSriov(t *testing.T) {
+	pfName, err := ResolveAllocationPf(types.SriovAllocationConfig{})
+	if err != nil {
+		t.Fatalf("ResolveAllocationPf: %v", err)
+	}
+	if pfName != "" {
+		t.Fatalf("got PF name %q for a non-SR-IOV allocation, want empty string", pfName)
+	}
+}
+
+func TestResolveAllocationPfFromCNIArgsDeviceID(t *testing.T) {
+	busPci, _ := withFakeSysfs(t)
+
+	const vfAddr = "0000:3b:00.1"
+	const pfAddr = "0000:3b:00.0"
+	writePciField(t, busPci, vfAddr, "vendor", "0x15b3")
+	writePciField(t, busPci, vfAddr, "device", "0x1018")
+	if err := os.Symlink(filepath.Join(busPci, pfAddr), filepath.Join(busPci, vfAddr, "physfn")); err != nil {
+		t.Fatalf("failed to symlink physfn: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(busPci, pfAddr, "net", "eth0"), 0o755); err != nil {
+		t.Fatalf("failed to create PF net dir: %v", err)
+	}
+
+	cfg := types.SriovAllocationConfig{
+		ResourceName: "intel.com/intel_sriov_netdevice",
+		PciDeviceID:  vfAddr,
+	}
+	pfName, err := ResolveAllocationPf(cfg)
+	if err != nil {
+		t.Fatalf("ResolveAllocationPf: %v", err)
+	}
+	if pfName != "eth0" {
+		t.Fatalf("got PF name %q, want %q", pfName, "eth0")
+	}
+}
@@ -0,0 +1,33 @@
+package kubernetes
+
+import "github.com/dougbtv/whereabouts/pkg/storage"
+
+// SriovPool pairs an IPPool with the SR-IOV resourceName/pfName it's
+// pinned to, e.g. a pool whose NetConf range config set `pfName: ens1f0`.
+// The CNI ADD path builds one of these per configured pool and passes them
+// to SelectPoolForPf to restrict allocation to the PF a request's VF
+// actually lives on.
+type SriovPool struct {
+	Pool         storage.IPPool
+	ResourceName string
+	PfName       string
+}
+
+// SelectPoolForPf returns the first pool among pools pinned to pfName. A
+// pool with an empty PfName is unpinned and serves as a fallback for any PF,
+// so existing non-SR-IOV pools keep working unchanged when mixed in with
+// pinned ones: the first unpinned pool is returned only when no pool is
+// pinned to pfName. Returns (SriovPool{}, false) when no pool matches pfName.
+func SelectPoolForPf(pools []SriovPool, pfName string) (SriovPool, bool) {
+	for _, pool := range pools {
+		if pool.PfName == pfName {
+			return pool, true
+		}
+	}
+	for _, pool := range pools {
+		if pool.PfName == "" {
+			return pool, true
+		}
+	}
+	return SriovPool{}, false
+}
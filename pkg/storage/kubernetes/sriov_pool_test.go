@@ -0,0 +1,157 @@
+package kubernetes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dougbtv/whereabouts/pkg/storage"
+	"github.com/dougbtv/whereabouts/pkg/types"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1"
+)
+
+// fakeIPPool implements storage.IPPool backed by an in-memory reservation
+// list, for exercising pool-selection logic without a real IPPool CRD.
+type fakeIPPool struct {
+	name        string
+	allocations []types.IPReservation
+}
+
+func (p *fakeIPPool) Allocations() []types.IPReservation { return p.allocations }
+
+func (p *fakeIPPool) Update(context.Context, []types.IPReservation) error { return nil }
+
+func TestSelectPoolForPfMatchesPinnedPool(t *testing.T) {
+	unpinned := &fakeIPPool{name: "default-pool"}
+	pinnedEns1f0 := &fakeIPPool{name: "ens1f0-pool"}
+	pinnedEns1f1 := &fakeIPPool{name: "ens1f1-pool"}
+
+	pools := []SriovPool{
+		{Pool: storage.IPPool(unpinned)},
+		{Pool: storage.IPPool(pinnedEns1f0), ResourceName: "intel.com/intel_sriov_netdevice", PfName: "ens1f0"},
+		{Pool: storage.IPPool(pinnedEns1f1), ResourceName: "intel.com/intel_sriov_netdevice", PfName: "ens1f1"},
+	}
+
+	got, ok := SelectPoolForPf(pools, "ens1f1")
+	if !ok {
+		t.Fatalf("expected a pool pinned to ens1f1 to be found")
+	}
+	if got.Pool != storage.IPPool(pinnedEns1f1) {
+		t.Fatalf("got pool %+v, want the ens1f1-pinned pool", got)
+	}
+}
+
+func TestSelectPoolForPfFallsBackToUnpinnedPool(t *testing.T) {
+	unpinned := &fakeIPPool{name: "default-pool"}
+	pinned := &fakeIPPool{name: "ens1f0-pool"}
+
+	pools := []SriovPool{
+		{Pool: storage.IPPool(pinned), ResourceName: "intel.com/intel_sriov_netdevice", PfName: "ens1f0"},
+		{Pool: storage.IPPool(unpinned)},
+	}
+
+	got, ok := SelectPoolForPf(pools, "ens1f1")
+	if !ok {
+		t.Fatalf("expected the unpinned pool to be selected as a fallback")
+	}
+	if got.Pool != storage.IPPool(unpinned) {
+		t.Fatalf("got pool %+v, want the unpinned pool", got)
+	}
+}
+
+func TestSelectPoolForPfNoMatch(t *testing.T) {
+	pinned := &fakeIPPool{name: "ens1f0-pool"}
+	pools := []SriovPool{
+		{Pool: storage.IPPool(pinned), ResourceName: "intel.com/intel_sriov_netdevice", PfName: "ens1f0"},
+	}
+
+	if _, ok := SelectPoolForPf(pools, "ens1f1"); ok {
+		t.Fatalf("expected no pool to match a PF nothing is pinned to")
+	}
+}
+
+// TestSriovPoolSelectionEndToEnd exercises the full SR-IOV pool-selection
+// path against fakes for both of its external dependencies: a fake sysfs
+// tree resolves the VF's PF from its CNI_ARGS-sourced PCI address, and a
+// fake PodResources server confirms kubelet still reports a device under
+// the pool's resourceName for the pod. SelectPoolForPf then
+// picks the pool pinned to the resolved PF - the same sequence the CNI ADD
+// path runs to decide which IPPool to allocate from.
+func TestSriovPoolSelectionEndToEnd(t *testing.T) {
+	busPci, _ := withFakeSysfs(t)
+
+	const podRef = "default/sriov-pod"
+	const resourceName = "intel.com/intel_sriov_netdevice"
+	const vfAddr = "0000:3b:00.1"
+	const pfAddr = "0000:3b:00.0"
+
+	writePciField(t, busPci, vfAddr, "vendor", "0x15b3")
+	writePciField(t, busPci, vfAddr, "device", "0x1018")
+	if err := os.Symlink(filepath.Join(busPci, pfAddr), filepath.Join(busPci, vfAddr, "physfn")); err != nil {
+		t.Fatalf("failed to symlink physfn: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(busPci, pfAddr, "net", "ens1f0"), 0o755); err != nil {
+		t.Fatalf("failed to create PF net dir: %v", err)
+	}
+
+	// The PodResources ID is deliberately different from vfAddr: it's
+	// opaque and plugin-specific, never a PCI address, so ResolveAllocationPf
+	// must not be fed it (see ResolveAllocationPf's doc comment).
+	const podResourcesDeviceID = "vfio-dev-17"
+	socketPath := startFakePodResourcesServer(t, &podresourcesapi.ListPodResourcesResponse{
+		PodResources: []*podresourcesapi.PodResources{
+			{
+				Namespace: "default",
+				Name:      "sriov-pod",
+				Containers: []*podresourcesapi.ContainerResources{
+					{
+						Devices: []*podresourcesapi.ContainerDevices{
+							{
+								ResourceName: resourceName,
+								DeviceIds:    []string{podResourcesDeviceID},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	podResourcesClient, err := NewPodResourcesClient(socketPath)
+	if err != nil {
+		t.Fatalf("NewPodResourcesClient: %v", err)
+	}
+	defer podResourcesClient.Close()
+
+	_, podDevices, err := podResourcesClient.ListPodRefs(context.Background())
+	if err != nil {
+		t.Fatalf("ListPodRefs: %v", err)
+	}
+	if !podDevices.HoldsDevice(podRef, podResourcesDeviceID) {
+		t.Fatalf("expected kubelet to still hold %s for %s", podResourcesDeviceID, podRef)
+	}
+
+	pfName, err := ResolveAllocationPf(types.SriovAllocationConfig{ResourceName: resourceName, PciDeviceID: vfAddr})
+	if err != nil {
+		t.Fatalf("ResolveAllocationPf: %v", err)
+	}
+	if pfName != "ens1f0" {
+		t.Fatalf("got PF name %q, want %q", pfName, "ens1f0")
+	}
+
+	unpinned := &fakeIPPool{name: "default-pool"}
+	wantPool := &fakeIPPool{name: "ens1f0-pool"}
+	pools := []SriovPool{
+		{Pool: storage.IPPool(unpinned), PfName: ""},
+		{Pool: storage.IPPool(wantPool), ResourceName: resourceName, PfName: "ens1f0"},
+	}
+
+	selected, ok := SelectPoolForPf(pools, pfName)
+	if !ok {
+		t.Fatalf("expected a pool pinned to %q to be selected", pfName)
+	}
+	if selected.Pool != storage.IPPool(wantPool) {
+		t.Fatalf("got pool %+v, want the ens1f0-pinned pool", selected)
+	}
+}
@@ -0,0 +1,151 @@
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withFakeSysfs points sysBusPci/sysClassNet at a scratch directory for the
+// duration of the test, so PF resolution can be exercised without real
+// SR-IOV hardware.
+func withFakeSysfs(t *testing.T) (busPci, classNet string) {
+	t.Helper()
+
+	root := t.TempDir()
+	busPci = filepath.Join(root, "bus-pci")
+	classNet = filepath.Join(root, "class-net")
+	for _, dir := range []string{busPci, classNet} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+
+	origBusPci, origClassNet := sysBusPci, sysClassNet
+	sysBusPci, sysClassNet = busPci, classNet
+	t.Cleanup(func() {
+		sysBusPci, sysClassNet = origBusPci, origClassNet
+	})
+
+	return busPci, classNet
+}
+
+func writePciField(t *testing.T, busPci, pciAddr, field, value string) {
+	t.Helper()
+	dir := filepath.Join(busPci, pciAddr)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("failed to create %s: %v", dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, field), []byte(value), 0o644); err != nil {
+		t.Fatalf("failed to write %s/%s: %v", dir, field, err)
+	}
+}
+
+func TestGetPfNameLegacyPhysfnSymlink(t *testing.T) {
+	busPci, _ := withFakeSysfs(t)
+
+	const vfAddr = "0000:3b:00.1"
+	const pfAddr = "0000:3b:00.0"
+	writePciField(t, busPci, vfAddr, "vendor", "0x15b3")
+	writePciField(t, busPci, vfAddr, "device", "0x1018")
+	if err := os.Symlink(filepath.Join(busPci, pfAddr), filepath.Join(busPci, vfAddr, "physfn")); err != nil {
+		t.Fatalf("failed to symlink physfn: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(busPci, pfAddr, "net", "eth0"), 0o755); err != nil {
+		t.Fatalf("failed to create PF net dir: %v", err)
+	}
+
+	pfName, err := GetPfName(vfAddr)
+	if err != nil {
+		t.Fatalf("GetPfName: %v", err)
+	}
+	if pfName != "eth0" {
+		t.Fatalf("got PF name %q, want %q", pfName, "eth0")
+	}
+}
+
+func TestGetPfNameNonVFReturnsEmpty(t *testing.T) {
+	busPci, _ := withFakeSysfs(t)
+
+	const pciAddr = "0000:3b:00.0"
+	writePciField(t, busPci, pciAddr, "vendor", "0x15b3")
+	writePciField(t, busPci, pciAddr, "device", "0x1018")
+
+	pfName, err := GetPfName(pciAddr)
+	if err != nil {
+		t.Fatalf("GetPfName: %v", err)
+	}
+	if pfName != "" {
+		t.Fatalf("got PF name %q for a non-VF device, want empty string", pfName)
+	}
+}
+
+func TestGetPfNameYusurSmartNIC(t *testing.T) {
+	busPci, classNet := withFakeSysfs(t)
+
+	const vfAddr = "0000:af:00.1"
+	writePciField(t, busPci, vfAddr, "vendor", "0x1f46")
+	writePciField(t, busPci, vfAddr, "device", "0x2100")
+	if err := os.MkdirAll(filepath.Join(busPci, "0000:af:00.0"), 0o755); err != nil {
+		t.Fatalf("failed to create PF dir: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(busPci, "0000:af:00.0"), filepath.Join(busPci, vfAddr, "physfn")); err != nil {
+		t.Fatalf("failed to symlink physfn: %v", err)
+	}
+
+	vfDir := filepath.Join(classNet, "pf0", "smart_nic", "vf0")
+	if err := os.MkdirAll(vfDir, 0o755); err != nil {
+		t.Fatalf("failed to create smart_nic VF dir: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(busPci, vfAddr), filepath.Join(vfDir, "device")); err != nil {
+		t.Fatalf("failed to symlink smart_nic device: %v", err)
+	}
+
+	pfName, err := GetPfName(vfAddr)
+	if err != nil {
+		t.Fatalf("GetPfName: %v", err)
+	}
+	if pfName != "pf0" {
+		t.Fatalf("got PF name %q, want %q", pfName, "pf0")
+	}
+}
+
+func TestRegisterPfResolverOverridesDefault(t *testing.T) {
+	withFakeSysfs(t)
+
+	const vendorID, deviceID = 0xdead, 0xbeef
+	const vfAddr = "0000:5e:00.1"
+	writePciField(t, sysBusPci, vfAddr, "vendor", "0xdead")
+	writePciField(t, sysBusPci, vfAddr, "device", "0xbeef")
+	if err := os.MkdirAll(filepath.Join(sysBusPci, "0000:5e:00.0"), 0o755); err != nil {
+		t.Fatalf("failed to create PF dir: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(sysBusPci, "0000:5e:00.0"), filepath.Join(sysBusPci, vfAddr, "physfn")); err != nil {
+		t.Fatalf("failed to symlink physfn: %v", err)
+	}
+
+	called := false
+	RegisterPfResolver(vendorID, deviceID, pfResolverFunc(func(pciAddr string) (string, error) {
+		called = true
+		return "custom-pf", nil
+	}))
+	t.Cleanup(func() { delete(pfResolvers, pfResolverKey{vendorID, deviceID}) })
+
+	pfName, err := GetPfName(vfAddr)
+	if err != nil {
+		t.Fatalf("GetPfName: %v", err)
+	}
+	if !called {
+		t.Fatalf("expected the registered PfResolver to be consulted")
+	}
+	if pfName != "custom-pf" {
+		t.Fatalf("got PF name %q, want %q", pfName, "custom-pf")
+	}
+}
+
+// pfResolverFunc adapts a function to the PfResolver interface for tests.
+type pfResolverFunc func(pciAddr string) (string, error)
+
+func (f pfResolverFunc) GetPfName(pciAddr string) (string, error) {
+	return f(pciAddr)
+}
@@ -0,0 +1,133 @@
+// Package types holds the data types shared across Whereabouts' IPAM
+// storage, allocation, and reconciliation code.
+package types
+
+import "net"
+
+// IPReservation describes a single IP address handed out by an IPPool.
+type IPReservation struct {
+	IP          net.IP
+	PodRef      string
+	IsAllocated bool
+
+	// PfName and DeviceID identify the SR-IOV PF/VF this reservation was
+	// handed out for, when the pool was pinned to a resourceName/pfName.
+	// Both are empty for non-SR-IOV allocations.
+	PfName   string
+	DeviceID string
+
+	// Policy controls whether the reconciler is allowed to garbage
+	// collect this reservation once its pod is gone. Defaults to
+	// ReleasePolicyAlwaysRelease.
+	Policy ReleasePolicy
+
+	// OwnerRef is the pod's immediate controller reference (e.g. its
+	// StatefulSet, or the ReplicaSet of a Deployment-managed pod),
+	// captured at allocation time while the pod still exists. The
+	// reconciler walks up from it to the top controller, and a
+	// ReleasePolicyImmutable reservation is only released once that
+	// controller chain, not just the pod, is gone. Zero value for a pod
+	// with no controller owner.
+	OwnerRef OwnerReference
+}
+
+// OwnerReference identifies the controller object owning a pod, resolved
+// from its ownerReferences at allocation time so the reconciler can later
+// check whether that controller still exists without depending on the pod
+// itself still being around to walk.
+type OwnerReference struct {
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+// ReleasePolicy controls whether ReconcileLooper may release an
+// IPReservation once the pod that owns it disappears.
+type ReleasePolicy string
+
+const (
+	// ReleasePolicyAlwaysRelease is the default: the reservation is
+	// released as soon as its pod is gone.
+	ReleasePolicyAlwaysRelease ReleasePolicy = ""
+	// ReleasePolicyNever keeps the reservation forever, even once its
+	// pod is gone. Useful for StatefulSet pods whose identity should
+	// keep a stable IP across restarts.
+	ReleasePolicyNever ReleasePolicy = "Never"
+	// ReleasePolicyImmutable keeps the reservation until the pod's
+	// owning workload (e.g. the StatefulSet itself), not just the
+	// individual pod, is deleted.
+	ReleasePolicyImmutable ReleasePolicy = "Immutable"
+)
+
+const (
+	// ReleasePolicyConfigKey is the IPAM config key operators can set to
+	// apply a release policy to every allocation made from that config,
+	// e.g. `"release_policy": "Immutable"`.
+	ReleasePolicyConfigKey = "release_policy"
+
+	// ReleasePolicyAnnotation is the pod annotation that overrides the
+	// IPAM config's release policy for that pod's own allocations.
+	ReleasePolicyAnnotation = "whereabouts.cni.cncf.io/release-policy"
+)
+
+// IsValid reports whether p is one of the known release policies.
+func (p ReleasePolicy) IsValid() bool {
+	switch p {
+	case ReleasePolicyAlwaysRelease, ReleasePolicyNever, ReleasePolicyImmutable:
+		return true
+	default:
+		return false
+	}
+}
+
+// ResolveReleasePolicy determines the release policy for a pod's
+// allocation, giving the pod's whereabouts.cni.cncf.io/release-policy
+// annotation precedence over the IPAM config's release_policy key, and
+// falling back to ReleasePolicyAlwaysRelease when neither is set.
+func ResolveReleasePolicy(podAnnotations map[string]string, configPolicy string) (ReleasePolicy, error) {
+	if raw, ok := podAnnotations[ReleasePolicyAnnotation]; ok && raw != "" {
+		return parseReleasePolicy(raw)
+	}
+	if configPolicy != "" {
+		return parseReleasePolicy(configPolicy)
+	}
+	return ReleasePolicyAlwaysRelease, nil
+}
+
+func parseReleasePolicy(raw string) (ReleasePolicy, error) {
+	if raw == "AlwaysRelease" {
+		return ReleasePolicyAlwaysRelease, nil
+	}
+	policy := ReleasePolicy(raw)
+	if !policy.IsValid() {
+		return "", &InvalidReleasePolicyError{Value: raw}
+	}
+	return policy, nil
+}
+
+// InvalidReleasePolicyError is returned when a release policy value isn't
+// one of Never, Immutable, or AlwaysRelease.
+type InvalidReleasePolicyError struct {
+	Value string
+}
+
+func (e *InvalidReleasePolicyError) Error() string {
+	return "invalid release policy: " + e.Value
+}
+
+// SriovAllocationConfig carries the SR-IOV resource pinning for a single
+// allocation request. The CNI ADD path threads it in from the pool's
+// resourceName/pfName config and the device-plugin PCI address published
+// via CNI_ARGS/runtimeConfig, so the allocator can resolve and stamp the PF
+// that backs a pod's VF onto the IPReservation it creates. Both fields are
+// empty for a non-SR-IOV allocation.
+type SriovAllocationConfig struct {
+	// ResourceName is the device-plugin resource name the pool is pinned
+	// to, e.g. "intel.com/intel_sriov_netdevice".
+	ResourceName string
+	// PciDeviceID is the PCI address (e.g. "0000:3b:00.1") of the VF
+	// kubelet assigned this pod for ResourceName. Unlike a kubelet
+	// PodResources device ID, which is opaque and plugin-specific, this
+	// is always a PCI address for SR-IOV CNI allocations.
+	PciDeviceID string
+}
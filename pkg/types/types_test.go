@@ -0,0 +1,57 @@
+package types
+
+import "testing"
+
+func TestResolveReleasePolicy(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		configValue string
+		want        ReleasePolicy
+		wantErr     bool
+	}{
+		{
+			name: "defaults to always-release when unset",
+			want: ReleasePolicyAlwaysRelease,
+		},
+		{
+			name:        "falls back to the IPAM config value",
+			configValue: "Never",
+			want:        ReleasePolicyNever,
+		},
+		{
+			name:        "pod annotation overrides the IPAM config value",
+			annotations: map[string]string{ReleasePolicyAnnotation: "Immutable"},
+			configValue: "Never",
+			want:        ReleasePolicyImmutable,
+		},
+		{
+			name:        "invalid config value is rejected",
+			configValue: "Sometimes",
+			wantErr:     true,
+		},
+		{
+			name:        "invalid annotation value is rejected",
+			annotations: map[string]string{ReleasePolicyAnnotation: "Sometimes"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ResolveReleasePolicy(tc.annotations, tc.configValue)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got policy %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got policy %q, want %q", got, tc.want)
+			}
+		})
+	}
+}